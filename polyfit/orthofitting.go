@@ -0,0 +1,206 @@
+package polyfit
+
+// OrthoFitting models a polynomial y from sample points xs and ys, the same
+// way Fitting does, but fits with a discrete orthogonal basis instead of
+// the monomial basis 1, x, x², ... .
+//
+// The monomial XᵀX Fitting builds becomes ill-conditioned at higher
+// degrees(roughly above 5-7), because the columns of X, 1, x, x², ...,
+// become nearly linearly dependent. OrthoFitting avoids this by
+// constructing a basis Φ₀, Φ₁, ... that is orthogonal over the sample
+// points, using the Forsythe three-term recurrence:
+//
+//    Φ₀(x) = 1
+//    Φ₁(x) = x - α₀
+//    Φⱼ₊₁(x) = (x - αⱼ)Φⱼ(x) - βⱼΦⱼ₋₁(x)
+//
+//    αⱼ = ∑ wᵢΦⱼ(xᵢ)²xᵢ / ∑ wᵢΦⱼ(xᵢ)²
+//    βⱼ = ∑ wᵢΦⱼ(xᵢ)² / ∑ wᵢΦⱼ₋₁(xᵢ)²
+//
+// Because the Φⱼ are orthogonal, the normal-equations matrix is diagonal
+// and the fitted coefficients are simply:
+//
+//    γⱼ = ∑ wᵢyᵢΦⱼ(xᵢ) / ∑ wᵢΦⱼ(xᵢ)²
+//
+// f(x) = ∑ γⱼΦⱼ(x) is the same polynomial Fitting would produce(up to
+// floating point error), but computing it this way never forms an
+// ill-conditioned matrix, so it stays accurate at degrees where Fitting's
+// Solve degrades. Use ToMonomial to convert γ back to the
+// f(x) = β₁ + β₂x + ... form used elsewhere in this package.
+//
+// This holds when there are at least Degree+1 distinct xs. With fewer,
+// some Φⱼ is zero at every sample point, ∑ wᵢΦⱼ(xᵢ)² is 0 or only
+// rounding noise, and the coefficients divided by it are NaN or
+// meaningless; OrthoFitting does not reduce the degree
+// the way Fitting's Solve does.
+//
+// Unlike Fitting, OrthoFitting is not incrementally mergeable: the basis
+// Φⱼ depends on the full set of sample points, so Add/Merge would have to
+// rebuild it from scratch anyway.
+//
+// Since 0.1.7
+type OrthoFitting struct {
+	Degree int
+	N      int
+
+	// alpha and beta are the recurrence coefficients, alpha[j] and
+	// beta[j] correspond to αⱼ and βⱼ above. beta[0] is unused(there is
+	// no Φ₋₁).
+	alpha []float64
+	beta  []float64
+
+	// coef[j] is γⱼ, the fitted coefficient of Φⱼ.
+	coef []float64
+}
+
+// NewOrthoFitting creates an OrthoFitting from points(xs, ys) and the
+// degree of the polynomial to fit.
+//
+// Since 0.1.7
+func NewOrthoFitting(xs, ys []float64, degree int) *OrthoFitting {
+	ws := make([]float64, len(xs))
+	for i := range ws {
+		ws[i] = 1
+	}
+	return NewWeightedOrthoFitting(xs, ys, ws, degree)
+}
+
+// NewWeightedOrthoFitting creates an OrthoFitting from points(xs, ys), a
+// per-point weight ws and the degree of the polynomial to fit, mirroring
+// NewWeightedFitting.
+//
+// Since 0.1.7
+func NewWeightedOrthoFitting(xs, ys, ws []float64, degree int) *OrthoFitting {
+
+	n := len(xs)
+	m := degree + 1
+
+	f := &OrthoFitting{
+		Degree: degree,
+		N:      n,
+
+		alpha: make([]float64, degree),
+		beta:  make([]float64, degree),
+		coef:  make([]float64, m),
+	}
+
+	phiPrev := make([]float64, n) // Φⱼ₋₁(xᵢ)
+	phiCur := make([]float64, n)  // Φⱼ(xᵢ)
+	for i := range phiCur {
+		phiCur[i] = 1
+	}
+
+	normPrev := float64(0) // ∑wΦⱼ₋₁²
+	normCur := float64(0)  // ∑wΦⱼ²
+	for i := 0; i < n; i++ {
+		normCur += ws[i] * phiCur[i] * phiCur[i]
+	}
+
+	f.coef[0] = sumWYPhi(ws, ys, phiCur) / normCur
+
+	for j := 0; j < degree; j++ {
+
+		alpha := sumWXPhi2(ws, xs, phiCur) / normCur
+		beta := float64(0)
+		if j > 0 {
+			beta = normCur / normPrev
+		}
+
+		phiNext := make([]float64, n)
+		for i := 0; i < n; i++ {
+			phiNext[i] = (xs[i] - alpha) * phiCur[i]
+			if j > 0 {
+				phiNext[i] -= beta * phiPrev[i]
+			}
+		}
+
+		normNext := float64(0)
+		for i := 0; i < n; i++ {
+			normNext += ws[i] * phiNext[i] * phiNext[i]
+		}
+
+		f.alpha[j] = alpha
+		f.beta[j] = beta
+		f.coef[j+1] = sumWYPhi(ws, ys, phiNext) / normNext
+
+		phiPrev, phiCur = phiCur, phiNext
+		normPrev, normCur = normCur, normNext
+	}
+
+	return f
+}
+
+func sumWYPhi(ws, ys, phi []float64) float64 {
+	s := float64(0)
+	for i := range phi {
+		s += ws[i] * ys[i] * phi[i]
+	}
+	return s
+}
+
+func sumWXPhi2(ws, xs, phi []float64) float64 {
+	s := float64(0)
+	for i := range phi {
+		s += ws[i] * phi[i] * phi[i] * xs[i]
+	}
+	return s
+}
+
+// SolveOrtho returns the fitted coefficients γ in the orthogonal basis,
+// i.e. f(x) = γ₀Φ₀(x) + γ₁Φ₁(x) + ... . This is deliberately not named
+// Solve: it is not interchangeable with Fitting.Solve, whose result is in
+// the monomial basis. Use ToMonomial to get coefficients in the
+// f(x) = β₁ + β₂x + ... form Fitting.Solve returns.
+//
+// Since 0.1.7
+func (f *OrthoFitting) SolveOrtho() []float64 {
+	rst := make([]float64, len(f.coef))
+	copy(rst, f.coef)
+	return rst
+}
+
+// ToMonomial converts the fitted polynomial into monomial-basis
+// coefficients, the same f(x) = β₁ + β₂x + β₃x² + ... form
+// Fitting.Solve returns, so downstream code can use OrthoFitting as a
+// drop-in numerically-stabler replacement for Fitting.
+//
+// Since 0.1.7
+func (f *OrthoFitting) ToMonomial() []float64 {
+
+	m := f.Degree + 1
+
+	// polyPrev/polyCur hold Φⱼ₋₁/Φⱼ as monomial coefficient vectors of
+	// length m, polyCur[k] is the coefficient of xᵏ.
+	polyPrev := make([]float64, m)
+	polyCur := make([]float64, m)
+	polyCur[0] = 1
+
+	beta := make([]float64, m)
+	beta[0] = f.coef[0]
+
+	for j := 0; j < f.Degree; j++ {
+
+		polyNext := make([]float64, m)
+		// (x - alpha[j]) * polyCur: shift polyCur up by one power of x,
+		// then subtract alpha[j]*polyCur.
+		for k := 0; k < m-1; k++ {
+			polyNext[k+1] += polyCur[k]
+		}
+		for k := 0; k < m; k++ {
+			polyNext[k] -= f.alpha[j] * polyCur[k]
+		}
+		if j > 0 {
+			for k := 0; k < m; k++ {
+				polyNext[k] -= f.beta[j] * polyPrev[k]
+			}
+		}
+
+		for k := 0; k < m; k++ {
+			beta[k] += f.coef[j+1] * polyNext[k]
+		}
+
+		polyPrev, polyCur = polyCur, polyNext
+	}
+
+	return beta
+}
@@ -0,0 +1,39 @@
+package polyfit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrthoFittingToMonomialMatchesFitting(t *testing.T) {
+
+	ta := require.New(t)
+
+	xs := []float64{1, 2, 3, 4, 5, 6}
+	ys := []float64{2.1, 3.9, 9.1, 16.2, 24.8, 36.1}
+
+	want := NewFitting(xs, ys, 2).Solve()
+
+	o := NewOrthoFitting(xs, ys, 2)
+	ta.InDeltaSlice(want, o.ToMonomial(), 1e-6)
+
+	// SolveOrtho returns coefficients in the orthogonal basis: they are
+	// not expected to match the monomial-basis β from Fitting.Solve, the
+	// footgun ToMonomial exists to avoid.
+	ta.NotEqual(want, o.SolveOrtho())
+}
+
+func TestOrthoFittingWeighted(t *testing.T) {
+
+	ta := require.New(t)
+
+	xs := []float64{1, 2, 3, 4, 5}
+	ys := []float64{1, 4, 9, 16, 25}
+	ws := []float64{1, 1, 1, 1, 1}
+
+	unweighted := NewOrthoFitting(xs, ys, 2)
+	weighted := NewWeightedOrthoFitting(xs, ys, ws, 2)
+
+	ta.InDeltaSlice(unweighted.ToMonomial(), weighted.ToMonomial(), 1e-9)
+}
@@ -14,6 +14,7 @@ package polyfit
 
 import (
 	"fmt"
+	"math"
 	"strings"
 
 	"gonum.org/v1/gonum/mat"
@@ -56,10 +57,32 @@ type Fitting struct {
 	N      int
 	Degree int
 
+	// Nsum is the sum of weights of every point added so far.
+	// For unweighted points(weight=1), Nsum == float64(N).
+	//
+	// Since 0.1.4
+	Nsum float64
+
+	// Lambda is the ridge(Tikhonov) regularization strength used by
+	// Solve: when it is non-zero, Solve solves the ridge-regularized
+	// equations as SolveRidge does, with the intercept unpenalized.
+	// SolveRidge itself takes λ as an argument and does not read this
+	// field. It is a field instead of a Solve() argument so callers
+	// can tune it once on a Fitting and reuse it, e.g. per-segment in
+	// SlimArray.
+	//
+	// Since 0.1.5
+	Lambda float64
+
 	// cache XᵀX
 	xtx []float64
 	// cache XᵀY
 	xty []float64
+
+	// cache ∑wy, for RSS/TSS
+	ysum float64
+	// cache ∑wy², for RSS/TSS
+	y2sum float64
 }
 
 // NewFitting creates a new polynomial fitting context, with points and the
@@ -95,6 +118,36 @@ func NewFitting(xs, ys []float64, degree int) *Fitting {
 	return f
 }
 
+// NewWeightedFitting creates a new polynomial fitting context with points,
+// a per-point weight and the degree of the polynomial.
+//
+// A bigger weight makes the corresponding point contribute more to the
+// fitted curve, e.g., it can be used to emphasize recent samples in a
+// time-decayed fit, or to express heteroscedastic data.
+// A weight of 1 for every point is equivalent to NewFitting.
+//
+// Since 0.1.4
+func NewWeightedFitting(xs, ys, ws []float64, degree int) *Fitting {
+
+	n := len(xs)
+
+	m := degree + 1
+
+	f := &Fitting{
+		N:      0,
+		Degree: degree,
+
+		xtx: make([]float64, m*m),
+		xty: make([]float64, m),
+	}
+
+	for i := 0; i < n; i++ {
+		f.AddWeighted(xs[i], ys[i], ws[i])
+	}
+
+	return f
+}
+
 // Copy into a new instance.
 //
 // Since 0.1.3
@@ -102,9 +155,14 @@ func (f *Fitting) Copy() *Fitting {
 	b := &Fitting{
 		N:      f.N,
 		Degree: f.Degree,
+		Nsum:   f.Nsum,
+		Lambda: f.Lambda,
 
 		xtx: make([]float64, 0, len(f.xtx)),
 		xty: make([]float64, 0, len(f.xty)),
+
+		ysum:  f.ysum,
+		y2sum: f.y2sum,
 	}
 
 	b.xtx = append(b.xtx, f.xtx...)
@@ -117,6 +175,17 @@ func (f *Fitting) Copy() *Fitting {
 //
 // Since 0.1.0
 func (f *Fitting) Add(x, y float64) {
+	f.AddWeighted(x, y, 1)
+}
+
+// AddWeighted adds a point(x, y) with a weight w into this fitting.
+//
+// The XᵀX and XᵀY accumulators become weighted sums:
+// ∑ wᵢ·xᵢ^(j+k) and ∑ wᵢ·xᵢʲ·yᵢ, which are still additive, thus Merge
+// keeps working for weighted fittings as well.
+//
+// Since 0.1.4
+func (f *Fitting) AddWeighted(x, y, w float64) {
 
 	m := f.Degree + 1
 
@@ -129,15 +198,63 @@ func (f *Fitting) Add(x, y float64) {
 
 	for i := 0; i < m; i++ {
 		for j := 0; j < m; j++ {
-			f.xtx[i*m+j] += xpows[i] * xpows[j]
+			f.xtx[i*m+j] += w * xpows[i] * xpows[j]
 		}
 	}
 
 	for i := 0; i < m; i++ {
-		f.xty[i] += xpows[i] * y
+		f.xty[i] += w * xpows[i] * y
 	}
 
+	f.ysum += w * y
+	f.y2sum += w * y * y
+
 	f.N++
+	f.Nsum += w
+}
+
+// Remove a point(x, y) previously added with Add, i.e. it undoes Add(x, y).
+//
+// Since the XᵀX, XᵀY, ∑y and ∑y² accumulators are all linear in the
+// points added, subtracting a point's contribution is exact, which makes
+// Fitting usable as a sliding-window regressor: Add the new point, Remove
+// the one that fell out of the window.
+//
+// Since 0.1.8
+func (f *Fitting) Remove(x, y float64) {
+	f.RemoveWeighted(x, y, 1)
+}
+
+// RemoveWeighted removes a point(x, y) with a weight w previously added
+// with AddWeighted, i.e. it undoes AddWeighted(x, y, w).
+//
+// Since 0.1.8
+func (f *Fitting) RemoveWeighted(x, y, w float64) {
+
+	m := f.Degree + 1
+
+	xpows := make([]float64, m)
+	v := float64(1)
+	for i := 0; i < m; i++ {
+		xpows[i] = v
+		v *= x
+	}
+
+	for i := 0; i < m; i++ {
+		for j := 0; j < m; j++ {
+			f.xtx[i*m+j] -= w * xpows[i] * xpows[j]
+		}
+	}
+
+	for i := 0; i < m; i++ {
+		f.xty[i] -= w * xpows[i] * y
+	}
+
+	f.ysum -= w * y
+	f.y2sum -= w * y * y
+
+	f.N--
+	f.Nsum -= w
 }
 
 // Merge two sets of sample data.
@@ -155,6 +272,9 @@ func (f *Fitting) Merge(b *Fitting) {
 	}
 
 	f.N += b.N
+	f.Nsum += b.Nsum
+	f.ysum += b.ysum
+	f.y2sum += b.y2sum
 
 	m := f.Degree + 1
 
@@ -166,6 +286,62 @@ func (f *Fitting) Merge(b *Fitting) {
 	}
 }
 
+// Sub removes the sample data of b from f, i.e. it is the inverse of
+// Merge: f.Merge(b); f.Sub(b) leaves f unchanged(up to floating point
+// error).
+//
+// Since 0.1.8
+func (f *Fitting) Sub(b *Fitting) {
+
+	if f.Degree != b.Degree {
+		panic(fmt.Sprintf("different degree: %d %d", f.Degree, b.Degree))
+	}
+
+	f.N -= b.N
+	f.Nsum -= b.Nsum
+	f.ysum -= b.ysum
+	f.y2sum -= b.y2sum
+
+	m := f.Degree + 1
+
+	for i := 0; i < m; i++ {
+		f.xty[i] -= b.xty[i]
+		for j := 0; j < m; j++ {
+			f.xtx[i*m+j] -= b.xtx[i*m+j]
+		}
+	}
+}
+
+// ExpDecay scales every cached accumulator(XᵀX, XᵀY, ∑y, ∑y² and Nsum) by
+// alpha, implementing exponential forgetting: calling ExpDecay(alpha)
+// followed by Add for every new point turns Fitting into an EWMA
+// polynomial regressor, where older points' influence decays by a factor
+// of alpha on every step. alpha is typically in (0, 1].
+//
+// N, the raw count of Add/AddWeighted calls, is left untouched: it is an
+// integer and repeatedly truncating it towards zero would make it get
+// stuck at 0 after only a few decay steps, while Nsum(the effective,
+// decayed point count used by Solve) keeps shrinking smoothly.
+//
+// Since 0.1.8
+func (f *Fitting) ExpDecay(alpha float64) {
+
+	m := f.Degree + 1
+
+	for i := 0; i < m*m; i++ {
+		f.xtx[i] *= alpha
+	}
+
+	for i := 0; i < m; i++ {
+		f.xty[i] *= alpha
+	}
+
+	f.ysum *= alpha
+	f.y2sum *= alpha
+
+	f.Nsum *= alpha
+}
+
 // Solve the equation and returns coefficients of the result polynomial.
 // The number of coefficients is f.Degree + 1.
 //
@@ -175,9 +351,17 @@ func (f *Fitting) Merge(b *Fitting) {
 // Since 0.1.0
 func (f *Fitting) Solve() []float64 {
 
+	if f.Lambda != 0 {
+		rst, _, _ := f.solveRidgeBeta(f.Lambda, true)
+		return rst
+	}
+
 	m := f.Degree + 1
 
-	if m <= f.N {
+	// Nsum is the effective number of points(sum of weights). A point with a
+	// tiny weight contributes little rank to XᵀX, so we use Nsum, not N, to
+	// decide whether the system is well-determined.
+	if float64(m) <= f.Nsum {
 		// quick path
 		rst := make([]float64, m)
 		if m == 1 {
@@ -195,9 +379,21 @@ func (f *Fitting) Solve() []float64 {
 	coef := mat.NewDense(m, m, f.xtx)
 	right := mat.NewDense(m, 1, f.xty)
 
-	if f.Degree+1 > f.N {
+	if float64(f.Degree+1) > f.Nsum {
 
 		m = f.N
+		if float64(m) > f.Nsum {
+			m = int(f.Nsum)
+		}
+		// A tiny or decayed Nsum(e.g. from AddWeighted with small weights,
+		// or a few rounds of ExpDecay) can truncate m to 0, which gonum's
+		// Slice bounds check does not catch when both indices are equal
+		// (it short-circuits on i==k) — it then panics on the raw
+		// negative-length slice expression below. There is always at
+		// least one row/col to solve for.
+		if m < 1 {
+			m = 1
+		}
 
 		coef = coef.Slice(0, m, 0, m).(*mat.Dense)
 		right = right.Slice(0, m, 0, 1).(*mat.Dense)
@@ -225,6 +421,227 @@ func (f *Fitting) Solve() []float64 {
 	return rst
 }
 
+// SolveRidge solves the ridge(Tikhonov) regularized normal equations
+// (XᵀX + λI)β = XᵀY instead of the plain XᵀX β = XᵀY used by Solve.
+// Adding λ to the diagonal of XᵀX lifts its smallest eigenvalues away from
+// 0, which is exactly the fix for the "near-singular" condition described
+// in Solve: collinear or under-determined input no longer blows up β.
+//
+// The intercept term(the β₁ coefficient, column/row 0 of XᵀX) is left
+// unpenalized by default, since shrinking it biases the fitted curve away
+// from the mean of y. Set unpenalizeIntercept to false to regularize it
+// too.
+//
+// Besides β, it returns the residual norm ‖XᵀXβ − XᵀY‖₂ of the
+// un-regularized normal equation, which indicates how much the ridge term
+// moved the solution, and cond, the condition number of (XᵀX + λI), so a
+// caller such as SlimArray can pick λ per segment.
+//
+// Since 0.1.5
+func (f *Fitting) SolveRidge(lambda float64, unpenalizeIntercept bool) (beta []float64, residualNorm float64, cond float64) {
+
+	rst, coef, b := f.solveRidgeBeta(lambda, unpenalizeIntercept)
+	m := f.Degree + 1
+
+	// residual of the un-regularized normal equation: XᵀXβ − XᵀY
+	var resid mat.Dense
+	resid.Mul(mat.NewDense(m, m, f.xtx), b)
+	resNorm := float64(0)
+	for i := 0; i < m; i++ {
+		d := resid.At(i, 0) - f.xty[i]
+		resNorm += d * d
+	}
+	resNorm = math.Sqrt(resNorm)
+
+	cond = mat.Cond(coef, 2)
+
+	return rst, resNorm, cond
+}
+
+// solveRidgeBeta solves the ridge-regularized normal equations and
+// returns β, the regularized matrix (XᵀX + λI) and β as an m×1 matrix,
+// without the O(m³) condition-number estimate SolveRidge also computes. Solve uses this when f.Lambda is set, so per-segment
+// diagnostics(RSS, R2, MaxAbsResidual, ...) that call Solve don't pay for
+// an SVD they never asked for.
+func (f *Fitting) solveRidgeBeta(lambda float64, unpenalizeIntercept bool) (beta []float64, coef *mat.Dense, b *mat.Dense) {
+
+	m := f.Degree + 1
+
+	xtx := make([]float64, len(f.xtx))
+	copy(xtx, f.xtx)
+
+	for i := 0; i < m; i++ {
+		if i == 0 && unpenalizeIntercept {
+			continue
+		}
+		xtx[i*m+i] += lambda
+	}
+
+	coef = mat.NewDense(m, m, xtx)
+	right := mat.NewDense(m, 1, f.xty)
+
+	b = &mat.Dense{}
+	err := b.Solve(coef, right)
+	_ = err
+
+	rst := make([]float64, m)
+	for i := 0; i < m; i++ {
+		rst[i] = b.At(i, 0)
+	}
+
+	return rst, coef, b
+}
+
+// GDConfig configures SolveGD, the gradient-descent alternative to
+// Solve/SolveRidge.
+//
+// Since 0.1.9
+type GDConfig struct {
+	// Alpha is the gradient-descent learning rate.
+	Alpha float64
+	// MaxIter caps the number of iterations.
+	MaxIter int
+	// Tol is the convergence tolerance: iteration stops once the
+	// gradient's L2 norm drops to or below Tol.
+	Tol float64
+	// Lambda is an optional L2(ridge) term added to the gradient, same
+	// effect as Fitting.Lambda/SolveRidge but applied iteratively. Like
+	// SolveRidge's default, the intercept(β₁, index 0) is left
+	// unpenalized.
+	Lambda float64
+	// Init is an optional warm-start for β, e.g. the result of a
+	// previous SolveGD, useful for incremental refits after Merge. If
+	// nil, β starts at all zeros.
+	Init []float64
+}
+
+// SolveGD fits β with batch gradient descent on the cached XᵀX/XᵀY,
+// instead of the closed-form solve gonum's LU/QR performs in Solve. The
+// squared-error loss ‖Xβ − Y‖² has gradient 2(XᵀXβ − XᵀY), so each
+// iteration only touches the m×m/m cached matrices — it costs O(m²)
+// regardless of N and never re-scans the raw points, and, unlike Solve,
+// does not depend on gonum's matrix solver at all.
+//
+// This trades the exactness of the closed-form solve for a
+// dependency-light, warm-startable path: pass a previous β as cfg.Init to
+// cheaply refine it after a Merge, which is attractive when N is large
+// and degree is moderate.
+//
+// Since 0.1.9
+func (f *Fitting) SolveGD(cfg GDConfig) []float64 {
+
+	m := f.Degree + 1
+
+	beta := make([]float64, m)
+	if cfg.Init != nil {
+		copy(beta, cfg.Init)
+	}
+
+	grad := make([]float64, m)
+
+	for iter := 0; iter < cfg.MaxIter; iter++ {
+
+		// grad = 2*(XᵀX·β − XᵀY), plus 2λβ for the optional L2 term(the
+		// intercept, i==0, is left unpenalized, matching SolveRidge's
+		// default).
+		gradNormSq := float64(0)
+		for i := 0; i < m; i++ {
+			g := -f.xty[i]
+			for j := 0; j < m; j++ {
+				g += f.xtx[i*m+j] * beta[j]
+			}
+			if i != 0 {
+				g += cfg.Lambda * beta[i]
+			}
+			g *= 2
+			grad[i] = g
+			gradNormSq += g * g
+		}
+
+		if math.Sqrt(gradNormSq) <= cfg.Tol {
+			break
+		}
+
+		for i := 0; i < m; i++ {
+			beta[i] -= cfg.Alpha * grad[i]
+		}
+	}
+
+	return beta
+}
+
+// RSS returns the residual sum of squares ∑wᵢ(f(xᵢ) − yᵢ)² of the fitted
+// polynomial, computed from the cached accumulators as YᵀY − βᵀXᵀY, so it
+// costs only O(m) on top of a Solve() and never re-scans the input points.
+//
+// Since 0.1.6
+func (f *Fitting) RSS() float64 {
+
+	beta := f.Solve()
+
+	rss := f.y2sum
+	for i, b := range beta {
+		rss -= b * f.xty[i]
+	}
+
+	return rss
+}
+
+// TSS returns the total sum of squares ∑wᵢ(yᵢ − ȳ)², the variance of y
+// around its(weighted) mean, computed from the cached ∑y and ∑y².
+//
+// Since 0.1.6
+func (f *Fitting) TSS() float64 {
+	return f.y2sum - f.ysum*f.ysum/f.Nsum
+}
+
+// R2 returns the coefficient of determination R² = 1 − RSS/TSS, measuring
+// how much better the fitted polynomial is than simply predicting the mean
+// of y. R² close to 1 means a good fit.
+//
+// Since 0.1.6
+func (f *Fitting) R2() float64 {
+
+	tss := f.TSS()
+	if tss == 0 {
+		return 1
+	}
+
+	return 1 - f.RSS()/tss
+}
+
+// MaxAbsResidual scans xs and ys and returns the largest |f(xᵢ) − yᵢ|
+// under the currently fitted polynomial. Unlike RSS/TSS/R2 it needs the
+// raw points, since the max can not be derived from the linear
+// accumulators.
+//
+// Since 0.1.6
+func (f *Fitting) MaxAbsResidual(xs, ys []float64) float64 {
+
+	beta := f.Solve()
+
+	maxResidual := float64(0)
+	for i, x := range xs {
+
+		v := float64(1)
+		fx := float64(0)
+		for _, b := range beta {
+			fx += b * v
+			v *= x
+		}
+
+		r := fx - ys[i]
+		if r < 0 {
+			r = -r
+		}
+		if r > maxResidual {
+			maxResidual = r
+		}
+	}
+
+	return maxResidual
+}
+
 func determinant2(v []float64) float64 {
 	a, b, c, d := v[0], v[1], v[2], v[3]
 	return a*d - b*c
@@ -0,0 +1,200 @@
+package polyfit
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFittingAddWeighted(t *testing.T) {
+
+	ta := require.New(t)
+
+	// Unweighted AddWeighted(x, y, 1) must agree with Add.
+	a := NewFitting([]float64{1, 2, 3}, []float64{1, 4, 9}, 2)
+	b := &Fitting{Degree: 2, xtx: make([]float64, 9), xty: make([]float64, 3)}
+	b.AddWeighted(1, 1, 1)
+	b.AddWeighted(2, 4, 1)
+	b.AddWeighted(3, 9, 1)
+
+	ta.Equal(a.Solve(), b.Solve())
+	ta.Equal(float64(a.N), b.Nsum)
+
+	// A point added with weight 2 should count the same as adding it twice.
+	c := &Fitting{Degree: 1, xtx: make([]float64, 4), xty: make([]float64, 2)}
+	c.AddWeighted(1, 1, 1)
+	c.AddWeighted(2, 2, 2)
+
+	d := &Fitting{Degree: 1, xtx: make([]float64, 4), xty: make([]float64, 2)}
+	d.AddWeighted(1, 1, 1)
+	d.AddWeighted(2, 2, 1)
+	d.AddWeighted(2, 2, 1)
+
+	ta.InDeltaSlice(d.Solve(), c.Solve(), 1e-9)
+	ta.Equal(3.0, c.Nsum)
+}
+
+func TestFittingRemove(t *testing.T) {
+
+	ta := require.New(t)
+
+	// Add(x, y) then Remove(x, y) must undo it exactly.
+	f := NewFitting([]float64{1, 2, 3}, []float64{1, 4, 9}, 2)
+	before := f.Copy()
+
+	f.Add(4, 16)
+	f.Remove(4, 16)
+
+	ta.Equal(before.xtx, f.xtx)
+	ta.Equal(before.xty, f.xty)
+	ta.Equal(before.N, f.N)
+	ta.Equal(before.Nsum, f.Nsum)
+	ta.Equal(before.ysum, f.ysum)
+	ta.Equal(before.y2sum, f.y2sum)
+
+	// Removing a point from a sliding window should reproduce the
+	// fitting of the points that remain.
+	window := NewFitting([]float64{1, 2, 3, 4}, []float64{1, 4, 9, 16}, 2)
+	window.Remove(1, 1)
+
+	want := NewFitting([]float64{2, 3, 4}, []float64{4, 9, 16}, 2)
+	ta.InDeltaSlice(want.Solve(), window.Solve(), 1e-9)
+}
+
+func TestFittingSub(t *testing.T) {
+
+	ta := require.New(t)
+
+	// Merge(b) then Sub(b) must undo it exactly.
+	a := NewFitting([]float64{1, 2, 3}, []float64{1, 4, 9}, 2)
+	before := a.Copy()
+
+	b := NewFitting([]float64{4, 5}, []float64{16, 25}, 2)
+
+	a.Merge(b)
+	a.Sub(b)
+
+	ta.Equal(before.xtx, a.xtx)
+	ta.Equal(before.xty, a.xty)
+	ta.Equal(before.N, a.N)
+	ta.Equal(before.Nsum, a.Nsum)
+	ta.Equal(before.ysum, a.ysum)
+	ta.Equal(before.y2sum, a.y2sum)
+}
+
+func TestFittingSolveSmallWeightDoesNotPanic(t *testing.T) {
+
+	ta := require.New(t)
+
+	// Regression test: a weighted fitting whose weights sum to less than
+	// 1 truncated the near-singular fallback's row/col count to 0,
+	// panicking with "slice bounds out of range" in Solve.
+	f := NewWeightedFitting([]float64{1, 2}, []float64{1, 2}, []float64{0.1, 0.1}, 1)
+
+	ta.NotPanics(func() {
+		f.Solve()
+	})
+}
+
+func TestFittingExpDecayThenSolveDoesNotPanic(t *testing.T) {
+
+	ta := require.New(t)
+
+	f := NewFitting([]float64{1, 2, 3}, []float64{1, 4, 9}, 2)
+
+	ta.NotPanics(func() {
+		for i := 0; i < 10; i++ {
+			f.ExpDecay(0.5)
+			f.Solve()
+		}
+	})
+}
+
+func TestFittingSolveRidge(t *testing.T) {
+
+	ta := require.New(t)
+
+	// Two collinear points make Solve's plain normal equations
+	// near-singular for degree 2; SolveRidge should still produce a
+	// finite, stable β and a residual/condition estimate.
+	xs := []float64{1, 2}
+	ys := []float64{1, 2}
+	f := NewFitting(xs, ys, 2)
+
+	beta, resNorm, cond := f.SolveRidge(1, true)
+	ta.Len(beta, 3)
+	for _, b := range beta {
+		ta.False(math.IsNaN(b))
+		ta.False(math.IsInf(b, 0))
+	}
+	ta.GreaterOrEqual(resNorm, 0.0)
+	ta.Greater(cond, 0.0)
+
+	// On a well-determined fit, a tiny λ should barely perturb β away
+	// from the unregularized solution.
+	g := NewFitting([]float64{1, 2, 3}, []float64{1, 4, 9}, 2)
+	plain := g.Solve()
+	ridged, _, _ := g.SolveRidge(1e-9, true)
+	ta.InDeltaSlice(plain, ridged, 1e-4)
+
+	// Fitting.Lambda makes Solve() forward to the ridge path.
+	g.Lambda = 1e-9
+	ta.InDeltaSlice(plain, g.Solve(), 1e-4)
+
+	// Penalizing the intercept too should change β₁ relative to leaving
+	// it unpenalized, for a large enough λ.
+	withIntercept, _, _ := g.SolveRidge(5, false)
+	withoutIntercept, _, _ := g.SolveRidge(5, true)
+	ta.NotEqual(withIntercept[0], withoutIntercept[0])
+}
+
+func TestFittingGoodnessOfFit(t *testing.T) {
+
+	ta := require.New(t)
+
+	// y = x² exactly: a degree-2 fit is perfect, RSS == 0, R² == 1 and
+	// the max residual is 0.
+	xs := []float64{1, 2, 3, 4, 5}
+	ys := []float64{1, 4, 9, 16, 25}
+	exact := NewFitting(xs, ys, 2)
+
+	ta.InDelta(0, exact.RSS(), 1e-6)
+	ta.InDelta(1, exact.R2(), 1e-6)
+	ta.InDelta(0, exact.MaxAbsResidual(xs, ys), 1e-6)
+	ta.Greater(exact.TSS(), 0.0)
+
+	// A degree-0(constant) fit of the same data can't capture the
+	// quadratic shape: RSS should be close to TSS, so R² close to 0, and
+	// MaxAbsResidual should be well above 0.
+	flat := NewFitting(xs, ys, 0)
+	ta.InDelta(flat.TSS(), flat.RSS(), 1e-6)
+	ta.InDelta(0, flat.R2(), 1e-6)
+	ta.Greater(flat.MaxAbsResidual(xs, ys), 1.0)
+}
+
+func TestFittingSolveGD(t *testing.T) {
+
+	ta := require.New(t)
+
+	// y = 2x + 1, well-scaled xs so plain batch GD converges.
+	xs := []float64{-1, 0, 1}
+	ys := []float64{-1, 1, 3}
+	f := NewFitting(xs, ys, 1)
+
+	want := f.Solve()
+
+	got := f.SolveGD(GDConfig{Alpha: 0.1, MaxIter: 10000, Tol: 1e-10})
+	ta.InDeltaSlice(want, got, 1e-4)
+
+	// A warm start close to the answer should need very few iterations
+	// to land within tolerance.
+	warm := f.SolveGD(GDConfig{Alpha: 0.1, MaxIter: 5, Tol: 1e-10, Init: []float64{0.99, 1.99}})
+	ta.InDeltaSlice(want, warm, 1e-2)
+
+	// The L2 term should shrink the slope(index 1) but, matching
+	// SolveRidge's default, leave the intercept(index 0) unpenalized.
+	ridged := f.SolveGD(GDConfig{Alpha: 0.1, MaxIter: 10000, Tol: 1e-10, Lambda: 1})
+	ta.InDelta(want[0], ridged[0], 1e-2)
+	ta.NotEqual(want[1], ridged[1])
+}